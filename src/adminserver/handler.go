@@ -0,0 +1,66 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// Handler serves adminserver's own tiny config API:
+//
+//	GET  /api/configs -> the full current models.SystemCfg
+//	PUT  /api/configs -> merges the JSON body onto the current config
+//
+// It is deliberately not a beego app: adminserver is meant to run as its
+// own process/container, independent of the UI's router.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/configs", configHandler)
+	return mux
+}
+
+func configHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, defaultStore.Get())
+	case http.MethodPut:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		cfg, err := defaultStore.Patch(body)
+		if err != nil {
+			log.Errorf("failed to apply config patch: %v", err)
+			http.Error(w, "invalid config payload", http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, cfg)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("failed to encode config response: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adminserver owns the canonical Harbor system configuration. It
+// is the single writer of that configuration; every other component
+// (starting with src/ui/config) talks to it over HTTP and caches what it
+// reads.
+package adminserver
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/secret"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+// store is the in-memory, mutex-guarded holder of the canonical config.
+// It is seeded once from the environment at startup, matching how the
+// settings used to be read directly by src/ui/config before this package
+// existed. It is not persisted: changes made through Patch last only as
+// long as the process.
+type store struct {
+	mu  sync.RWMutex
+	cfg models.SystemCfg
+}
+
+var defaultStore = &store{cfg: seedFromEnv()}
+
+func seedFromEnv() models.SystemCfg {
+	port, _ := strconv.Atoi(os.Getenv("EMAIL_PORT"))
+	ssl, _ := strconv.ParseBool(os.Getenv("EMAIL_SSL"))
+	insecure, _ := strconv.ParseBool(os.Getenv("EMAIL_INSECURE"))
+
+	cfg := models.SystemCfg{
+		AuthMode:    os.Getenv("AUTH_MODE"),
+		ExtEndpoint: os.Getenv("EXT_ENDPOINT"),
+		Email: models.EmailSetting{
+			Host:     os.Getenv("EMAIL_HOST"),
+			Port:     port,
+			Identity: os.Getenv("EMAIL_IDENTITY"),
+			Username: os.Getenv("EMAIL_USR"),
+			Password: os.Getenv("EMAIL_PWD"),
+			SSL:      ssl,
+			Insecure: insecure,
+			From:     os.Getenv("EMAIL_FROM"),
+		},
+	}
+
+	// secret-tagged fields (e.g. Email.Password) are read above in
+	// plaintext, but src/ui/config.Reload always decrypts them -- encrypt
+	// now so the store holds the same ciphertext it would after a PUT
+	// /api/configs, instead of poisoning every config read on the UI side.
+	kr, err := secret.NewKeyring()
+	if err != nil {
+		log.Errorf("failed to load secret keyring, seeded secret fields left blank: %v", err)
+		secret.Transform(&cfg, func(string) (string, error) { return "", nil })
+		return cfg
+	}
+	if err := secret.EncryptStruct(&cfg, kr); err != nil {
+		log.Errorf("failed to encrypt seeded configuration, seeded secret fields left blank: %v", err)
+		secret.Transform(&cfg, func(string) (string, error) { return "", nil })
+	}
+	return cfg
+}
+
+// Get returns a copy of the current configuration.
+func (s *store) Get() models.SystemCfg {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// Patch merges a partial JSON document (the body of PUT /api/configs) onto
+// the current configuration and returns the result. Unknown fields are
+// ignored; fields present in raw overwrite the corresponding field in cfg,
+// fields absent from raw are left untouched.
+func (s *store) Patch(raw []byte) (models.SystemCfg, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merged := s.cfg
+	if err := json.Unmarshal(raw, &merged); err != nil {
+		return models.SystemCfg{}, err
+	}
+	s.cfg = merged
+	return s.cfg, nil
+}
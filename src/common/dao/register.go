@@ -82,3 +82,35 @@ func UserExists(user models.User, target string) (bool, error) {
 		return true, nil
 	}
 }
+
+// GetUser looks a user up by whichever of Email, Username or ResetUUID is
+// set on query, returning nil (not an error) if no row matches.
+func GetUser(query models.User) (*models.User, error) {
+	o := GetOrmer()
+
+	sql := `select user_id, username, email, realname, comment, sysadmin_flag, reset_uuid, reset_expires_at, creation_time, update_time from user where 1=1 `
+	params := []interface{}{}
+
+	switch {
+	case query.ResetUUID != "":
+		sql += ` and reset_uuid = ? `
+		params = append(params, query.ResetUUID)
+	case query.Email != "":
+		sql += ` and email = ? `
+		params = append(params, query.Email)
+	case query.Username != "":
+		sql += ` and username = ? `
+		params = append(params, query.Username)
+	default:
+		return nil, errors.New("at least one of email, username or reset_uuid must be set")
+	}
+
+	var users []models.User
+	if _, err := o.Raw(sql, params).QueryRows(&users); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+	return &users[0], nil
+}
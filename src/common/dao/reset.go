@@ -0,0 +1,79 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"errors"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils"
+)
+
+// resetTokenTTL is how long a password-reset link stays valid after it's
+// emailed out.
+const resetTokenTTL = 30 * time.Minute
+
+// ErrResetTokenExpired is returned by ResetUserPassword when no row holds
+// the given reset_uuid within resetTokenTTL of its issue time: the token
+// has expired, or it was already used and cleared.
+var ErrResetTokenExpired = errors.New("reset token has expired or already been used")
+
+// UpdateUserResetUUID sets user.ResetUUID (matched by email) and stamps
+// reset_expires_at to now()+30m, so the link this UUID will be emailed in
+// stops working after resetTokenTTL.
+func UpdateUserResetUUID(user models.User) error {
+	o := GetOrmer()
+
+	p, err := o.Raw("update user set reset_uuid = ?, reset_expires_at = ? where email = ?").Prepare()
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	_, err = p.Exec(user.ResetUUID, time.Now().Add(resetTokenTTL), user.Email)
+	return err
+}
+
+// ResetUserPassword sets a new password for the user identified by
+// user.ResetUUID and, in the same statement, clears the UUID and its
+// expiry so the link can't be reused. It only matches rows where the UUID
+// still matches and reset_expires_at hasn't passed; if the update affects
+// zero rows, it returns ErrResetTokenExpired rather than silently
+// succeeding.
+func ResetUserPassword(user models.User) error {
+	o := GetOrmer()
+
+	salt := utils.GenerateRandomString()
+	p, err := o.Raw(`update user set password = ?, salt = ?, reset_uuid = null, reset_expires_at = null
+		where reset_uuid = ? and reset_expires_at > ?`).Prepare()
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	result, err := p.Exec(utils.Encrypt(user.Password, salt), salt, user.ResetUUID, time.Now())
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrResetTokenExpired
+	}
+	return nil
+}
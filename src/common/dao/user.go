@@ -0,0 +1,84 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"github.com/vmware/harbor/src/common/models"
+)
+
+const defaultListUsersPageSize = 25
+
+// listUsersRow projects a single result row of the ListUsers query: the
+// user columns plus the window-function total, so the page and the total
+// count come back from one round-trip instead of two.
+type listUsersRow struct {
+	models.User
+	TotalCount int64 `orm:"column(total_count)"`
+}
+
+// ListUsers returns the page of users matching query, along with the
+// total number of users that match the filter regardless of pagination.
+// The count comes from `count(*) over()`, an ANSI window function
+// supported by both the MySQL (8+) and SQLite backends used in tests, so
+// a single query serves both the page and the total; only a page that
+// lies past the last match takes a second, plain count query.
+func ListUsers(query models.UserQuery) ([]models.User, int64, error) {
+	o := GetOrmer()
+
+	where := ` from user where 1=1 `
+	params := []interface{}{}
+
+	if query.Username != "" {
+		where += ` and username like ? `
+		params = append(params, "%"+query.Username+"%")
+	}
+	if query.Email != "" {
+		where += ` and email like ? `
+		params = append(params, "%"+query.Email+"%")
+	}
+	sql := `select user_id, username, email, realname, comment, sysadmin_flag, creation_time, update_time,
+		count(*) over() as total_count ` + where
+
+	page, pageSize := query.Page, query.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultListUsersPageSize
+	}
+
+	sql += ` order by user_id limit ? offset ? `
+	params = append(params, pageSize, (page-1)*pageSize)
+
+	var rows []listUsersRow
+	if _, err := o.Raw(sql, params).QueryRows(&rows); err != nil {
+		return nil, 0, err
+	}
+
+	users := make([]models.User, 0, len(rows))
+	var total int64
+	for _, r := range rows {
+		users = append(users, r.User)
+		total = r.TotalCount
+	}
+	// A page past the last match has no row to carry the window total,
+	// so count the filter's matches on their own.
+	if len(rows) == 0 && page > 1 {
+		if err := o.Raw(`select count(*) `+where, params[:len(params)-2]).QueryRow(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+	return users, total, nil
+}
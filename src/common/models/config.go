@@ -0,0 +1,38 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+// EmailSetting holds the SMTP settings used to send reset-password mail.
+type EmailSetting struct {
+	Host     string `json:"email_host"`
+	Port     int    `json:"email_port"`
+	Identity string `json:"email_identity"`
+	Username string `json:"email_username"`
+	Password string `json:"email_password" secret:"true"`
+	SSL      bool   `json:"email_ssl"`
+	Insecure bool   `json:"email_insecure"`
+	From     string `json:"email_from"`
+}
+
+// SystemCfg is the canonical, adminserver-owned configuration snapshot.
+// It is what src/ui/config caches and what POST /api/configurations
+// partially updates.
+type SystemCfg struct {
+	AuthMode      string         `json:"auth_mode"`
+	ExtEndpoint   string         `json:"ext_endpoint"`
+	Email         EmailSetting   `json:"email"`
+	LDAP          LdapConf       `json:"ldap"`
+	OIDCProviders []OIDCProvider `json:"oidc_providers"`
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+// LdapConf holds the connection and search settings needed to bind to an
+// LDAP/AD server and look up users. A few zero values get a default in
+// src/common/utils/ldap: no ConnectionTimeout means 5 seconds, no port in
+// URL means 389 (636 for ldaps), no Filter means "(<UID>=*)" and no
+// SearchDN means an anonymous bind. The other fields are used as given.
+type LdapConf struct {
+	URL               string `json:"ldap_url"`
+	SearchDN          string `json:"ldap_search_dn"`
+	SearchPwd         string `json:"ldap_search_password" secret:"true"`
+	BaseDN            string `json:"ldap_base_dn"`
+	Filter            string `json:"ldap_filter"`
+	UID               string `json:"ldap_uid"`
+	Scope             int    `json:"ldap_scope"`              // 0: base, 1: one level, 2: subtree
+	ConnectionTimeout int    `json:"ldap_connection_timeout"` // seconds
+	VerifyCert        bool   `json:"ldap_verify_cert"`
+}
+
+// LdapUser is a single entry returned from an LDAP search, trimmed to the
+// attributes Harbor cares about.
+type LdapUser struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Realname string `json:"realname"`
+	DN       string `json:"dn"`
+}
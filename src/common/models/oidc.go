@@ -0,0 +1,46 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+// OIDCClaimMapping describes which claims of an ID token map to Harbor
+// user attributes. Names can be overridden per-provider because not every
+// identity provider uses the OIDC-standard claim names.
+type OIDCClaimMapping struct {
+	Username string `json:"username"` // defaults to "preferred_username"
+	Email    string `json:"email"`    // defaults to "email"
+	Groups   string `json:"groups"`   // defaults to "groups"
+}
+
+// OIDCGroupRoleMapping maps a group claim value to a Harbor project role.
+// RoleID follows the same convention as models.Role (e.g. common.RoleProjectAdmin).
+type OIDCGroupRoleMapping struct {
+	Group  string `json:"group"`
+	RoleID int    `json:"role_id"`
+}
+
+// OIDCProvider is the persisted configuration for a single named OIDC/OAuth2
+// provider. Multiple providers can be configured at once; the `:provider`
+// path segment on the oauth routes selects which one to use.
+type OIDCProvider struct {
+	Name         string                 `json:"name"`
+	Issuer       string                 `json:"issuer"`
+	ClientID     string                 `json:"client_id"`
+	ClientSecret string                 `json:"client_secret" secret:"true"`
+	RedirectURL  string                 `json:"redirect_url"`
+	Scopes       []string               `json:"scopes"`
+	CABundle     string                 `json:"ca_bundle,omitempty"` // PEM-encoded, empty means use the system pool
+	ClaimMapping OIDCClaimMapping       `json:"claim_mapping"`
+	GroupRoles   []OIDCGroupRoleMapping `json:"group_roles,omitempty"`
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"reflect"
+	"strings"
+)
+
+// transform is Keyring.Encrypt or Keyring.Decrypt.
+type transform func(string) (string, error)
+
+// Transform walks v (a pointer to a struct, or a struct/slice nested
+// inside one) and applies fn in place to every string field tagged
+// `secret:"true"`, including ones nested in child structs and slices of
+// structs.
+func Transform(v interface{}, fn transform) error {
+	return transformValue(reflect.ValueOf(v), fn)
+}
+
+func transformValue(rv reflect.Value, fn transform) error {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		return transformValue(rv.Elem(), fn)
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fv := rv.Field(i)
+			if !fv.CanSet() {
+				continue
+			}
+			if field.Tag.Get("secret") == "true" && fv.Kind() == reflect.String {
+				out, err := fn(fv.String())
+				if err != nil {
+					return err
+				}
+				fv.SetString(out)
+				continue
+			}
+			if err := transformValue(fv, fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := transformValue(rv.Index(i), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EncryptStruct is Transform with kr.Encrypt.
+func EncryptStruct(v interface{}, kr *Keyring) error {
+	return Transform(v, kr.Encrypt)
+}
+
+// DecryptStruct is Transform with kr.Decrypt.
+func DecryptStruct(v interface{}, kr *Keyring) error {
+	return Transform(v, kr.Decrypt)
+}
+
+// SensitiveJSONKeys returns the set of JSON field names that are tagged
+// `secret:"true"` anywhere in sampleType (a struct, e.g. models.SystemCfg),
+// recursing into nested structs and slices. It's used to apply encryption
+// to a raw map[string]interface{} config patch -- the shape the UI posts
+// to /api/configurations -- without hard-coding field names.
+func SensitiveJSONKeys(sampleType reflect.Type) map[string]bool {
+	keys := map[string]bool{}
+	collectSensitiveKeys(sampleType, keys)
+	return keys
+}
+
+func collectSensitiveKeys(t reflect.Type, out map[string]bool) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		collectSensitiveKeys(t.Elem(), out)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" {
+				name = field.Name
+			}
+			if field.Tag.Get("secret") == "true" {
+				out[name] = true
+			}
+			collectSensitiveKeys(field.Type, out)
+		}
+	}
+}
+
+// TransformJSON walks a decoded JSON value (map[string]interface{},
+// []interface{}, or a scalar) and applies fn in place to every string
+// value whose map key is in sensitive.
+func TransformJSON(v interface{}, sensitive map[string]bool, fn transform) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, child := range val {
+			if s, ok := child.(string); ok && sensitive[key] {
+				out, err := fn(s)
+				if err != nil {
+					return err
+				}
+				val[key] = out
+				continue
+			}
+			if err := TransformJSON(child, sensitive, fn); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range val {
+			if err := TransformJSON(child, sensitive, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var errBoom = errors.New("boom")
+
+type testInner struct {
+	Password string `json:"password" secret:"true"`
+	Note     string `json:"note"`
+}
+
+type testOuter struct {
+	Name     string      `json:"name"`
+	Secret   string      `json:"secret" secret:"true"`
+	Inner    testInner   `json:"inner"`
+	Children []testInner `json:"children"`
+}
+
+func TestTransformWalksNestedStructsAndSlices(t *testing.T) {
+	v := testOuter{
+		Name:   "plain",
+		Secret: "toplevel",
+		Inner:  testInner{Password: "nested", Note: "plain"},
+		Children: []testInner{
+			{Password: "childzero", Note: "plain"},
+			{Password: "childone", Note: "plain"},
+		},
+	}
+
+	upper := func(s string) (string, error) { return strings.ToUpper(s), nil }
+
+	if err := Transform(&v, upper); err != nil {
+		t.Fatalf("Transform: %v", err)
+	}
+
+	if v.Name != "plain" {
+		t.Errorf("untagged field Name was transformed: got %q", v.Name)
+	}
+	if v.Secret != "TOPLEVEL" {
+		t.Errorf("top-level secret field not transformed: got %q", v.Secret)
+	}
+	if v.Inner.Password != "NESTED" {
+		t.Errorf("nested struct secret field not transformed: got %q", v.Inner.Password)
+	}
+	if v.Inner.Note != "plain" {
+		t.Errorf("untagged nested field was transformed: got %q", v.Inner.Note)
+	}
+	if v.Children[0].Password != "CHILDZERO" || v.Children[1].Password != "CHILDONE" {
+		t.Errorf("secret fields in a slice of structs not transformed: %+v", v.Children)
+	}
+}
+
+func TestTransformPropagatesError(t *testing.T) {
+	v := testOuter{Secret: "boom"}
+	failer := func(string) (string, error) { return "", errBoom }
+
+	if err := Transform(&v, failer); err != errBoom {
+		t.Fatalf("Transform error = %v, want %v", err, errBoom)
+	}
+}
+
+func TestEncryptDecryptStructRoundTrip(t *testing.T) {
+	kr := mustKeyring(t, make([]byte, 32))
+
+	v := testOuter{
+		Name:   "plain",
+		Secret: "s3cr3t",
+		Inner:  testInner{Password: "inner-secret"},
+	}
+
+	if err := EncryptStruct(&v, kr); err != nil {
+		t.Fatalf("EncryptStruct: %v", err)
+	}
+	if v.Secret == "s3cr3t" {
+		t.Fatalf("EncryptStruct left Secret in plaintext")
+	}
+	if v.Inner.Password == "inner-secret" {
+		t.Fatalf("EncryptStruct left Inner.Password in plaintext")
+	}
+
+	if err := DecryptStruct(&v, kr); err != nil {
+		t.Fatalf("DecryptStruct: %v", err)
+	}
+	if v.Secret != "s3cr3t" || v.Inner.Password != "inner-secret" {
+		t.Fatalf("EncryptStruct/DecryptStruct did not round trip: %+v", v)
+	}
+	if v.Name != "plain" {
+		t.Fatalf("untagged field changed across round trip: %q", v.Name)
+	}
+}
+
+func TestSensitiveJSONKeys(t *testing.T) {
+	got := SensitiveJSONKeys(reflect.TypeOf(testOuter{}))
+	want := map[string]bool{"secret": true, "password": true}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SensitiveJSONKeys = %v, want %v", got, want)
+	}
+}
+
+func TestTransformJSON(t *testing.T) {
+	sensitive := map[string]bool{"secret": true, "password": true}
+	upper := func(s string) (string, error) { return s + "!", nil }
+
+	doc := map[string]interface{}{
+		"name":   "plain",
+		"secret": "a",
+		"inner": map[string]interface{}{
+			"password": "b",
+			"note":     "plain",
+		},
+		"children": []interface{}{
+			map[string]interface{}{"password": "c"},
+		},
+	}
+
+	if err := TransformJSON(doc, sensitive, upper); err != nil {
+		t.Fatalf("TransformJSON: %v", err)
+	}
+
+	if doc["name"] != "plain" {
+		t.Errorf("untagged top-level key transformed: %v", doc["name"])
+	}
+	if doc["secret"] != "a!" {
+		t.Errorf("top-level sensitive key not transformed: %v", doc["secret"])
+	}
+	inner := doc["inner"].(map[string]interface{})
+	if inner["password"] != "b!" {
+		t.Errorf("nested sensitive key not transformed: %v", inner["password"])
+	}
+	if inner["note"] != "plain" {
+		t.Errorf("untagged nested key transformed: %v", inner["note"])
+	}
+	children := doc["children"].([]interface{})
+	child := children[0].(map[string]interface{})
+	if child["password"] != "c!" {
+		t.Errorf("sensitive key inside a JSON array not transformed: %v", child["password"])
+	}
+}
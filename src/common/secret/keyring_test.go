@@ -0,0 +1,143 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import "testing"
+
+func mustKeyring(t *testing.T, key []byte) *Keyring {
+	t.Helper()
+	kr, err := NewKeyringFromBytes(key)
+	if err != nil {
+		t.Fatalf("NewKeyringFromBytes: %v", err)
+	}
+	return kr
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	cases := []struct {
+		name      string
+		key       []byte
+		plaintext string
+	}{
+		{"aes-128 short string", make([]byte, 16), "hunter2"},
+		{"aes-256 short string", make([]byte, 32), "hunter2"},
+		{"unicode", make([]byte, 32), "pässwörd™"},
+		{"long string", make([]byte, 32), string(make([]byte, 4096))},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kr := mustKeyring(t, c.key)
+
+			ciphertext, err := kr.Encrypt(c.plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if ciphertext == c.plaintext {
+				t.Fatalf("Encrypt returned plaintext unchanged")
+			}
+
+			got, err := kr.Decrypt(ciphertext)
+			if err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+			if got != c.plaintext {
+				t.Fatalf("round trip mismatch: got %q, want %q", got, c.plaintext)
+			}
+		})
+	}
+}
+
+func TestEncryptIsNonDeterministic(t *testing.T) {
+	kr := mustKeyring(t, make([]byte, 32))
+
+	a, err := kr.Encrypt("hunter2")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	b, err := kr.Encrypt("hunter2")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if a == b {
+		t.Fatalf("two encryptions of the same plaintext produced identical ciphertext (nonce reuse?)")
+	}
+}
+
+func TestEncryptEmptyStringRoundTrips(t *testing.T) {
+	kr := mustKeyring(t, make([]byte, 32))
+
+	ciphertext, err := kr.Encrypt("")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if ciphertext != "" {
+		t.Fatalf("Encrypt(\"\") = %q, want \"\"", ciphertext)
+	}
+
+	plaintext, err := kr.Decrypt("")
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if plaintext != "" {
+		t.Fatalf("Decrypt(\"\") = %q, want \"\"", plaintext)
+	}
+}
+
+func TestDecryptRejectsMalformedCiphertext(t *testing.T) {
+	kr := mustKeyring(t, make([]byte, 32))
+
+	cases := []struct {
+		name      string
+		encoded   string
+		wantError bool
+	}{
+		{"not base64", "not-valid-base64!!", true},
+		{"too short", "YQ==", true}, // decodes to a single byte, shorter than the GCM nonce
+		{"valid base64 but not real ciphertext", "dGhpcyBpcyBub3QgcmVhbCBjaXBoZXJ0ZXh0", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := kr.Decrypt(c.encoded)
+			if (err != nil) != c.wantError {
+				t.Fatalf("Decrypt(%q) error = %v, wantError %v", c.encoded, err, c.wantError)
+			}
+		})
+	}
+}
+
+func TestDecryptFailsUnderWrongKey(t *testing.T) {
+	krA := mustKeyring(t, make([]byte, 32))
+	krB := mustKeyring(t, append(make([]byte, 31), 1))
+
+	ciphertext, err := krA.Encrypt("hunter2")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if _, err := krB.Decrypt(ciphertext); err == nil {
+		t.Fatalf("Decrypt succeeded under the wrong key")
+	}
+}
+
+func TestNewKeyringFromBytesRejectsBadKeySize(t *testing.T) {
+	// Only 16- and 32-byte keys are accepted; anything else must be rejected.
+	sizes := []int{0, 1, 8, 15, 17, 33}
+	for _, size := range sizes {
+		if _, err := NewKeyringFromBytes(make([]byte, size)); err == nil {
+			t.Errorf("NewKeyringFromBytes(%d bytes) succeeded, want error", size)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ldap wraps gopkg.in/ldap.v2 with the handful of operations
+// Harbor needs: a search-account bind, a single user search and a bind
+// test, all driven off a models.LdapConf.
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+	"gopkg.in/ldap.v2"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// Session is a short-lived connection to an LDAP server, configured from a
+// models.LdapConf. Callers must Close it when done.
+type Session struct {
+	conf models.LdapConf
+	conn *ldap.Conn
+}
+
+// NewSession dials (but does not bind) the server described by conf.
+func NewSession(conf models.LdapConf) (*Session, error) {
+	timeout := defaultTimeout
+	if conf.ConnectionTimeout > 0 {
+		timeout = time.Duration(conf.ConnectionTimeout) * time.Second
+	}
+
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ldap url %s: %v", conf.URL, err)
+	}
+	host := u.Host
+	if !strings.Contains(host, ":") {
+		if u.Scheme == "ldaps" {
+			host += ":636"
+		} else {
+			host += ":389"
+		}
+	}
+
+	// ldap.v2 has no per-dial timeout option; DefaultTimeout is the
+	// package-global knob its Dial/DialTLS use.
+	ldap.DefaultTimeout = timeout
+
+	var conn *ldap.Conn
+	if u.Scheme == "ldaps" {
+		conn, err = ldap.DialTLS("tcp", host, &tls.Config{
+			InsecureSkipVerify: !conf.VerifyCert,
+			ServerName:         u.Hostname(),
+		})
+	} else {
+		conn, err = ldap.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", conf.URL, err)
+	}
+
+	return &Session{conf: conf, conn: conn}, nil
+}
+
+// Close releases the underlying connection.
+func (s *Session) Close() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
+// Bind performs a search-account bind using the configured SearchDN/SearchPwd.
+// A server-side failure is returned as the unwrapped *ldap.Error, so
+// ResultCode can still read its result code.
+func (s *Session) Bind() error {
+	if s.conf.SearchDN == "" {
+		return nil // anonymous bind
+	}
+	return s.conn.Bind(s.conf.SearchDN, s.conf.SearchPwd)
+}
+
+// BindAs attempts to bind as dn using password, reusing this session's
+// connection. It is used to verify an end-user's own credentials after
+// SearchUsers has resolved their DN.
+func (s *Session) BindAs(dn, password string) error {
+	if err := s.conn.Bind(dn, password); err != nil {
+		return fmt.Errorf("bind as %s failed: %v", dn, err)
+	}
+	return nil
+}
+
+// SearchUsers runs conf.Filter (or the default "(<UID>=*)" filter) under
+// conf.BaseDN, ANDed with "(<UID>=uidValue)" when uidValue is non-empty,
+// and returns the matching entries projected onto models.LdapUser.
+func (s *Session) SearchUsers(uidValue string) ([]models.LdapUser, error) {
+	filter := s.conf.Filter
+	if filter == "" {
+		filter = fmt.Sprintf("(%s=*)", s.conf.UID)
+	}
+	if uidValue != "" {
+		filter = fmt.Sprintf("(&%s(%s=%s))", filter, s.conf.UID, ldap.EscapeFilter(uidValue))
+	}
+
+	req := ldap.NewSearchRequest(
+		s.conf.BaseDN,
+		s.conf.Scope,
+		ldap.NeverDerefAliases,
+		0, 0, false,
+		filter,
+		[]string{s.conf.UID, "mail", "cn"},
+		nil,
+	)
+
+	res, err := s.conn.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]models.LdapUser, 0, len(res.Entries))
+	for _, entry := range res.Entries {
+		users = append(users, models.LdapUser{
+			Username: entry.GetAttributeValue(s.conf.UID),
+			Email:    entry.GetAttributeValue("mail"),
+			Realname: entry.GetAttributeValue("cn"),
+			DN:       entry.DN,
+		})
+	}
+	return users, nil
+}
+
+// ResultCode extracts the numeric LDAP result code from err, or -1 if err
+// didn't originate from the LDAP server (e.g. a network failure).
+func ResultCode(err error) int {
+	if err == nil {
+		return ldap.LDAPResultSuccess
+	}
+	if ldapErr, ok := err.(*ldap.Error); ok {
+		return int(ldapErr.ResultCode)
+	}
+	return -1
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit provides a small in-memory, per-key token-bucket
+// limiter. It's process-local (no shared store), which is the right
+// tradeoff for throttling things like password-reset requests: a false
+// negative after a UI restart is harmless, and it avoids a dependency on
+// a shared cache just for this.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter grants `burst` tokens per key, refilled continuously over
+// `per`. E.g. New(3, time.Hour) allows 3 events/hour/key, with the bucket
+// refilling gradually rather than resetting in a hard window.
+type Limiter struct {
+	burst float64
+	per   time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// New creates a Limiter allowing `burst` events per `per` duration, per key.
+func New(burst int, per time.Duration) *Limiter {
+	return &Limiter{
+		burst:   float64(burst),
+		per:     per,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow reports whether an event for key is allowed right now, consuming
+// a token if so. When it returns false, retryAfter is how long the caller
+// should wait before the next token becomes available.
+func (l *Limiter) Allow(key string) (allowed bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, lastSeen: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.lastSeen)
+	b.tokens += elapsed.Seconds() * (l.burst / l.per.Seconds())
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.lastSeen = now
+
+	l.sweep(now)
+
+	if b.tokens < 1 {
+		perToken := l.per / time.Duration(l.burst)
+		missing := 1 - b.tokens
+		return false, time.Duration(missing * float64(perToken))
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops buckets idle for at least one refill period, so an
+// attacker cycling through distinct keys (e.g. emails or IPs passed to
+// SendResetEmail) can't grow buckets without bound. Idle time alone is
+// the right test regardless of the bucket's remaining tokens: once
+// elapsed >= per, the refill math in Allow would cap tokens back to
+// l.burst on the next hit anyway, so dropping the entry now and
+// recreating it fresh later is equivalent to keeping it around. It runs
+// at most once per `per` duration, amortizing the scan over the keys
+// it's protecting against. Callers must hold l.mu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.per {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.per {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowGrantsBurstThenBlocks(t *testing.T) {
+	l := New(3, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if allowed, retryAfter := l.Allow("a"); !allowed {
+			t.Fatalf("call %d: Allow = false, %v; want true within burst", i, retryAfter)
+		}
+	}
+
+	allowed, retryAfter := l.Allow("a")
+	if allowed {
+		t.Fatalf("Allow = true after burst exhausted, want false")
+	}
+	if retryAfter <= 0 {
+		t.Fatalf("retryAfter = %v, want > 0", retryAfter)
+	}
+}
+
+func TestAllowRetryAfterIsRoughlyOnePeriodPerToken(t *testing.T) {
+	l := New(1, time.Minute)
+
+	if allowed, _ := l.Allow("a"); !allowed {
+		t.Fatalf("first call should be allowed")
+	}
+	_, retryAfter := l.Allow("a")
+
+	// burst=1, per=1m -> one token refills over the full minute, and the
+	// bucket is empty immediately after the first call, so retryAfter
+	// should be close to a full minute.
+	want := time.Minute
+	tolerance := time.Second
+	if retryAfter < want-tolerance || retryAfter > want {
+		t.Fatalf("retryAfter = %v, want within %v of %v", retryAfter, tolerance, want)
+	}
+}
+
+func TestAllowKeysAreIndependent(t *testing.T) {
+	l := New(1, time.Hour)
+
+	if allowed, _ := l.Allow("a"); !allowed {
+		t.Fatalf("first call for key a should be allowed")
+	}
+	if allowed, _ := l.Allow("a"); allowed {
+		t.Fatalf("second call for key a should be blocked")
+	}
+	if allowed, _ := l.Allow("b"); !allowed {
+		t.Fatalf("first call for key b should be allowed regardless of key a's state")
+	}
+}
+
+func TestAllowRefillsOverTime(t *testing.T) {
+	l := New(1, time.Hour)
+
+	if allowed, _ := l.Allow("a"); !allowed {
+		t.Fatalf("first call should be allowed")
+	}
+	if allowed, _ := l.Allow("a"); allowed {
+		t.Fatalf("second immediate call should be blocked")
+	}
+
+	// Rewrite the bucket's clock as if a full period has already elapsed,
+	// rather than sleeping for real in the test.
+	l.mu.Lock()
+	l.buckets["a"].lastSeen = time.Now().Add(-time.Hour)
+	l.mu.Unlock()
+
+	if allowed, _ := l.Allow("a"); !allowed {
+		t.Fatalf("call after a full refill period should be allowed")
+	}
+}
+
+func TestSweepDropsOnlyIdleBuckets(t *testing.T) {
+	l := New(2, time.Minute)
+	now := time.Now()
+
+	l.buckets["full-idle"] = &bucket{tokens: 2, lastSeen: now.Add(-2 * time.Minute)}
+	l.buckets["full-recent"] = &bucket{tokens: 2, lastSeen: now}
+	l.buckets["partial-idle"] = &bucket{tokens: 1, lastSeen: now.Add(-2 * time.Minute)}
+	l.buckets["empty-recent"] = &bucket{tokens: 0, lastSeen: now}
+
+	l.sweep(now)
+
+	if _, ok := l.buckets["full-idle"]; ok {
+		t.Errorf("sweep did not drop a full, idle-for-longer-than-per bucket")
+	}
+	if _, ok := l.buckets["full-recent"]; !ok {
+		t.Errorf("sweep dropped a full but recently-used bucket")
+	}
+	if _, ok := l.buckets["partial-idle"]; ok {
+		t.Errorf("sweep did not drop a partially-consumed but idle-for-longer-than-per bucket " +
+			"(idle time alone should be enough: the refill math would cap it back to full anyway)")
+	}
+	if _, ok := l.buckets["empty-recent"]; !ok {
+		t.Errorf("sweep dropped a recently-used bucket just because it's out of tokens")
+	}
+}
+
+func TestSweepIsRateLimited(t *testing.T) {
+	l := New(2, time.Minute)
+	now := time.Now()
+
+	l.buckets["full-idle"] = &bucket{tokens: 2, lastSeen: now.Add(-2 * time.Minute)}
+	l.lastSweep = now.Add(-time.Second) // well within the last `per` window
+
+	l.sweep(now)
+
+	if _, ok := l.buckets["full-idle"]; !ok {
+		t.Errorf("sweep ran again inside its own period; it should be a no-op until `per` has elapsed")
+	}
+}
+
+func TestAllowBoundsMapGrowthUnderManyKeys(t *testing.T) {
+	l := New(1, time.Millisecond)
+
+	for i := 0; i < 1000; i++ {
+		key := string(rune(i))
+		l.Allow(key)
+		// Let every bucket refill and age out before the next key arrives,
+		// simulating an attacker cycling through distinct emails/IPs.
+		l.mu.Lock()
+		for _, b := range l.buckets {
+			b.lastSeen = time.Now().Add(-time.Hour)
+		}
+		l.lastSweep = time.Now().Add(-time.Hour)
+		l.mu.Unlock()
+	}
+
+	l.mu.Lock()
+	size := len(l.buckets)
+	l.mu.Unlock()
+
+	if size > 10 {
+		t.Fatalf("buckets map grew to %d entries for 1000 distinct keys; sweep should keep it bounded", size)
+	}
+}
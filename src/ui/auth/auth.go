@@ -0,0 +1,56 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth dispatches login requests to the authenticator registered
+// for the active AUTH_MODE (db_auth, ldap_auth; OIDC logs in through its
+// own /oauth routes and never goes through Login).
+package auth
+
+import (
+	"fmt"
+
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/ui/config"
+)
+
+// Authenticator validates a principal/credential pair for one AUTH_MODE.
+type Authenticator interface {
+	Authenticate(m models.AuthModel) (*models.User, error)
+}
+
+var registry = make(map[string]Authenticator)
+
+// Register makes an Authenticator available under the given AUTH_MODE
+// name. It is meant to be called from the init() of each authenticator
+// implementation's package.
+func Register(mode string, a Authenticator) {
+	registry[mode] = a
+}
+
+// Login authenticates against whichever Authenticator is registered for
+// the current AUTH_MODE. How credentials that match no user are reported
+// depends on that Authenticator: db_auth returns whatever dao.LoginByDB
+// returns, ldap_auth returns ldap.ErrInvalidCredential.
+func Login(m models.AuthModel) (*models.User, error) {
+	mode, err := config.AuthMode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth mode: %v", err)
+	}
+
+	a, ok := registry[mode]
+	if !ok {
+		return nil, fmt.Errorf("no authenticator registered for auth mode %q", mode)
+	}
+	return a.Authenticate(m)
+}
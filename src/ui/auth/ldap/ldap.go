@@ -0,0 +1,89 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ldap implements auth.Authenticator against an external LDAP/AD
+// server, for AUTH_MODE=ldap_auth. It distinguishes network/bind failures
+// from an ordinary "no such user" so CommonController.Login can surface a
+// useful error instead of a blanket 401.
+package ldap
+
+import (
+	"fmt"
+
+	"github.com/vmware/harbor/src/common"
+	"github.com/vmware/harbor/src/common/models"
+	ldaputil "github.com/vmware/harbor/src/common/utils/ldap"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/auth"
+	"github.com/vmware/harbor/src/ui/config"
+)
+
+// ErrInvalidCredential is returned when the search bind succeeded but the
+// principal's own bind (or the search for it) did not find a match.
+var ErrInvalidCredential = fmt.Errorf("invalid credentials")
+
+type authenticator struct{}
+
+func (a *authenticator) Authenticate(m models.AuthModel) (*models.User, error) {
+	conf, err := config.LDAPConf()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ldap configuration: %v", err)
+	}
+
+	session, err := ldaputil.NewSession(*conf)
+	if err != nil {
+		return nil, fmt.Errorf("ldap connection failed: %v", err)
+	}
+	defer session.Close()
+
+	if err := session.Bind(); err != nil {
+		return nil, fmt.Errorf("ldap search bind failed: %v", err)
+	}
+
+	users, err := session.SearchUsers(m.Principal)
+	if err != nil {
+		return nil, fmt.Errorf("ldap search failed: %v", err)
+	}
+	if len(users) == 0 {
+		return nil, ErrInvalidCredential
+	}
+	if len(users) > 1 {
+		log.Warningf("ldap search for %q matched %d entries, using the first", m.Principal, len(users))
+	}
+	matched := users[0]
+
+	userSession, err := ldaputil.NewSession(*conf)
+	if err != nil {
+		return nil, fmt.Errorf("ldap connection failed: %v", err)
+	}
+	defer userSession.Close()
+
+	if err := userSession.BindAs(matched.DN, m.Password); err != nil {
+		return nil, ErrInvalidCredential
+	}
+
+	u := &models.User{
+		Username: matched.Username,
+		Email:    matched.Email,
+		Realname: matched.Realname,
+	}
+	if err := auth.OnBoardUser(u); err != nil {
+		return nil, fmt.Errorf("failed to onboard ldap user: %v", err)
+	}
+	return u, nil
+}
+
+func init() {
+	auth.Register(common.LDAPAuth, &authenticator{})
+}
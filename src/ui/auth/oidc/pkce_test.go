@@ -0,0 +1,83 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodeChallengeS256Vectors(t *testing.T) {
+	// Vector from RFC 7636 appendix B.
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	const want = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if got := CodeChallengeS256(verifier); got != want {
+		t.Fatalf("CodeChallengeS256(%q) = %q, want %q", verifier, got, want)
+	}
+}
+
+func TestCodeChallengeS256IsDeterministic(t *testing.T) {
+	a := CodeChallengeS256("same-verifier")
+	b := CodeChallengeS256("same-verifier")
+	if a != b {
+		t.Fatalf("CodeChallengeS256 is not deterministic: %q != %q", a, b)
+	}
+}
+
+func TestCodeChallengeS256DiffersPerVerifier(t *testing.T) {
+	a := CodeChallengeS256("verifier-a")
+	b := CodeChallengeS256("verifier-b")
+	if a == b {
+		t.Fatalf("CodeChallengeS256 produced the same challenge for different verifiers")
+	}
+}
+
+func TestNewCodeVerifierIsURLSafeAndUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		v, err := NewCodeVerifier()
+		if err != nil {
+			t.Fatalf("NewCodeVerifier: %v", err)
+		}
+		if len(v) < 43 || len(v) > 128 {
+			t.Fatalf("NewCodeVerifier length %d out of RFC 7636 range [43, 128]: %q", len(v), v)
+		}
+		if strings.ContainsAny(v, "+/=") {
+			t.Fatalf("NewCodeVerifier is not URL-safe base64: %q", v)
+		}
+		if seen[v] {
+			t.Fatalf("NewCodeVerifier produced a duplicate: %q", v)
+		}
+		seen[v] = true
+	}
+}
+
+func TestNewStateIsURLSafeAndUnique(t *testing.T) {
+	a, err := NewState()
+	if err != nil {
+		t.Fatalf("NewState: %v", err)
+	}
+	b, err := NewState()
+	if err != nil {
+		t.Fatalf("NewState: %v", err)
+	}
+	if a == b {
+		t.Fatalf("NewState produced the same value twice: %q", a)
+	}
+	if strings.ContainsAny(a, "+/=") || strings.ContainsAny(b, "+/=") {
+		t.Fatalf("NewState is not URL-safe base64: %q, %q", a, b)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"context"
+	"fmt"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/vmware/harbor/src/common/models"
+	"golang.org/x/oauth2"
+)
+
+// Claims holds the subset of a verified id_token that Harbor cares about,
+// after the provider's configured claim names have been mapped onto it.
+type Claims struct {
+	jwtgo.StandardClaims
+	PreferredUsername string
+	Email             string
+	Groups            []string
+}
+
+// Exchange trades an authorization code (plus its PKCE code_verifier) for a
+// token set, then verifies the returned id_token and returns its mapped
+// claims. nonce must be the value that was embedded in the original
+// AuthCodeURL call and stashed in the session.
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, c.http)
+
+	token, err := c.oauth2.Exchange(ctx, code,
+		oauth2.SetAuthURLParam("code_verifier", codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: code exchange failed: %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("oidc: token response did not include an id_token")
+	}
+
+	return c.verifyIDToken(rawIDToken, nonce)
+}
+
+func (c *Client) verifyIDToken(raw, wantNonce string) (*Claims, error) {
+	// Parsed as MapClaims rather than StandardClaims: StandardClaims.Audience
+	// is a plain string, so an id_token with a JSON-array aud (Azure AD,
+	// Google, Keycloak multi-audience tokens) fails to unmarshal and is
+	// rejected before audience is ever checked. MapClaims.VerifyAudience
+	// accepts both the string and array forms.
+	claims := jwtgo.MapClaims{}
+
+	parsed, err := jwtgo.ParseWithClaims(raw, claims, func(t *jwtgo.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwtgo.SigningMethodRSA, *jwtgo.SigningMethodECDSA:
+			kid, _ := t.Header["kid"].(string)
+			if kid == "" {
+				return nil, fmt.Errorf("oidc: id_token is missing a kid header")
+			}
+			return c.jwks.keyForKID(c.discover.JWKSURL, kid)
+		case *jwtgo.SigningMethodHMAC:
+			return []byte(c.cfg.ClientSecret), nil
+		default:
+			return nil, fmt.Errorf("oidc: unsupported signing method %v", t.Header["alg"])
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oidc: id_token signature verification failed: %v", err)
+	}
+	if !parsed.Valid {
+		return nil, fmt.Errorf("oidc: id_token is not valid")
+	}
+
+	iss, _ := claims["iss"].(string)
+	if iss != c.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: id_token issuer %q does not match provider issuer %q", iss, c.cfg.Issuer)
+	}
+	if !claims.VerifyAudience(c.cfg.ClientID, true) {
+		return nil, fmt.Errorf("oidc: id_token audience does not include client_id %q", c.cfg.ClientID)
+	}
+	if nonce, _ := claims["nonce"].(string); nonce == "" || nonce != wantNonce {
+		return nil, fmt.Errorf("oidc: id_token nonce does not match the authorization request")
+	}
+
+	return &Claims{
+		StandardClaims: jwtgo.StandardClaims{
+			Issuer:  iss,
+			Subject: stringClaim(claims, "sub"),
+		},
+		PreferredUsername: stringClaim(claims, c.claimName("username", "preferred_username")),
+		Email:             stringClaim(claims, c.claimName("email", "email")),
+		Groups:            stringSliceClaim(claims, c.claimName("groups", "groups")),
+	}, nil
+}
+
+func (c *Client) claimName(field, standardName string) string {
+	switch field {
+	case "username":
+		if c.cfg.ClaimMapping.Username != "" {
+			return c.cfg.ClaimMapping.Username
+		}
+	case "email":
+		if c.cfg.ClaimMapping.Email != "" {
+			return c.cfg.ClaimMapping.Email
+		}
+	case "groups":
+		if c.cfg.ClaimMapping.Groups != "" {
+			return c.cfg.ClaimMapping.Groups
+		}
+	}
+	return standardName
+}
+
+func stringClaim(claims jwtgo.MapClaims, name string) string {
+	v, _ := claims[name].(string)
+	return v
+}
+
+func stringSliceClaim(claims jwtgo.MapClaims, name string) []string {
+	raw, ok := claims[name].([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// MapRole returns the Harbor project role ID to grant based on the
+// provider's group-to-role mapping, or 0 if none of the user's groups
+// match a configured mapping. This is a project-level role (see
+// models.OIDCGroupRoleMapping) -- it must never be written to a user's
+// global sysadmin_flag, which is a distinct, unrelated value space.
+func (c *Client) MapRole(claims *Claims) int {
+	for _, gr := range c.cfg.GroupRoles {
+		for _, g := range claims.Groups {
+			if g == gr.Group {
+				return gr.RoleID
+			}
+		}
+	}
+	return 0
+}
+
+// ToUser maps verified claims onto a models.User ready for
+// auth.OnBoardUser. It does not set a password: OIDC users authenticate
+// against the provider, never against Harbor's local credential store.
+func (c *Client) ToUser(claims *Claims) (*models.User, error) {
+	if claims.PreferredUsername == "" {
+		return nil, fmt.Errorf("oidc: id_token did not provide a username claim")
+	}
+	return &models.User{
+		Username: claims.PreferredUsername,
+		Email:    claims.Email,
+	}, nil
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+const testClientSecret = "test-client-secret"
+
+func testClient() *Client {
+	return &Client{
+		Name: "test",
+		cfg: models.OIDCProvider{
+			Name:         "test",
+			Issuer:       "https://issuer.example.com",
+			ClientID:     "test-client-id",
+			ClientSecret: testClientSecret,
+		},
+	}
+}
+
+func signHS256(t *testing.T, claims jwtgo.MapClaims) string {
+	t.Helper()
+	token := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, claims)
+	raw, err := token.SignedString([]byte(testClientSecret))
+	if err != nil {
+		t.Fatalf("failed to sign test id_token: %v", err)
+	}
+	return raw
+}
+
+func baseClaims(c *Client) jwtgo.MapClaims {
+	return jwtgo.MapClaims{
+		"iss":                c.cfg.Issuer,
+		"sub":                "user-1",
+		"nonce":              "test-nonce",
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifyIDTokenAcceptsStringAudience(t *testing.T) {
+	c := testClient()
+	claims := baseClaims(c)
+	claims["aud"] = c.cfg.ClientID
+
+	got, err := c.verifyIDToken(signHS256(t, claims), "test-nonce")
+	if err != nil {
+		t.Fatalf("verifyIDToken: %v", err)
+	}
+	if got.PreferredUsername != "alice" {
+		t.Fatalf("PreferredUsername = %q, want %q", got.PreferredUsername, "alice")
+	}
+}
+
+func TestVerifyIDTokenAcceptsArrayAudience(t *testing.T) {
+	// Regression test: Azure AD / Google / Keycloak multi-audience
+	// id_tokens encode aud as a JSON array rather than a bare string.
+	c := testClient()
+	claims := baseClaims(c)
+	claims["aud"] = []string{"some-other-client", c.cfg.ClientID}
+
+	got, err := c.verifyIDToken(signHS256(t, claims), "test-nonce")
+	if err != nil {
+		t.Fatalf("verifyIDToken rejected a valid JSON-array aud claim: %v", err)
+	}
+	if got.Email != "alice@example.com" {
+		t.Fatalf("Email = %q, want %q", got.Email, "alice@example.com")
+	}
+}
+
+func TestVerifyIDTokenRejectsArrayAudienceMissingClientID(t *testing.T) {
+	c := testClient()
+	claims := baseClaims(c)
+	claims["aud"] = []string{"some-other-client", "yet-another-client"}
+
+	if _, err := c.verifyIDToken(signHS256(t, claims), "test-nonce"); err == nil {
+		t.Fatalf("verifyIDToken accepted an array aud that does not include client_id")
+	}
+}
+
+func TestVerifyIDTokenRejectsWrongIssuer(t *testing.T) {
+	c := testClient()
+	claims := baseClaims(c)
+	claims["iss"] = "https://not-the-configured-issuer.example.com"
+	claims["aud"] = c.cfg.ClientID
+
+	if _, err := c.verifyIDToken(signHS256(t, claims), "test-nonce"); err == nil {
+		t.Fatalf("verifyIDToken accepted a token from an unexpected issuer")
+	}
+}
+
+func TestVerifyIDTokenRejectsWrongNonce(t *testing.T) {
+	c := testClient()
+	claims := baseClaims(c)
+	claims["aud"] = c.cfg.ClientID
+
+	if _, err := c.verifyIDToken(signHS256(t, claims), "a-different-nonce"); err == nil {
+		t.Fatalf("verifyIDToken accepted a token with a mismatched nonce")
+	}
+}
+
+func TestVerifyIDTokenRejectsBadSignature(t *testing.T) {
+	c := testClient()
+	claims := baseClaims(c)
+	claims["aud"] = c.cfg.ClientID
+
+	token := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, claims)
+	raw, err := token.SignedString([]byte("not-the-configured-secret"))
+	if err != nil {
+		t.Fatalf("failed to sign test id_token: %v", err)
+	}
+
+	if _, err := c.verifyIDToken(raw, "test-nonce"); err == nil {
+		t.Fatalf("verifyIDToken accepted a token signed with the wrong secret")
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// OnBoardUser provisions a local user record for a principal that has
+// already been authenticated by an external identity source (LDAP, OIDC)
+// or just self-registered against the local DB. It is the single path all
+// auth modes should use to go from "authenticated elsewhere" to "has a
+// Harbor user_id" -- callers must not insert the user record themselves.
+//
+// If a user with the same username already exists it is left untouched
+// and u is populated with its existing UserID.
+func OnBoardUser(u *models.User) error {
+	return dao.OnBoardUser(u)
+}
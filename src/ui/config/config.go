@@ -0,0 +1,195 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config is the UI container's client for adminserver. It fetches
+// the canonical models.SystemCfg over HTTP and caches it in-process for
+// CFG_EXPIRATION seconds, so the hot paths in controllers (SendResetEmail,
+// the oauth/ldap handlers, isUserResetable, ...) block on a network
+// round-trip only when the cache is empty or has expired. Call Reload to
+// invalidate the cache immediately, e.g. after
+// POST /api/configurations writes a change through adminserver.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/secret"
+)
+
+const defaultCfgExpiration = 5 * time.Second
+
+var (
+	mu            sync.RWMutex
+	cached        *models.SystemCfg
+	cachedAt      time.Time
+	cfgExpiration = defaultCfgExpiration
+	httpClient    = &http.Client{Timeout: 10 * time.Second}
+)
+
+func init() {
+	if secs, err := strconv.Atoi(os.Getenv("CFG_EXPIRATION")); err == nil && secs > 0 {
+		cfgExpiration = time.Duration(secs) * time.Second
+	}
+}
+
+var (
+	keyringOnce sync.Once
+	keyring     *secret.Keyring
+	keyringErr  error
+)
+
+func getKeyring() (*secret.Keyring, error) {
+	keyringOnce.Do(func() {
+		keyring, keyringErr = secret.NewKeyring()
+	})
+	return keyring, keyringErr
+}
+
+func adminserverURL() string {
+	if url := os.Getenv("ADMINSERVER_URL"); url != "" {
+		return url
+	}
+	return "http://adminserver"
+}
+
+// GetSystemCfg returns the cached configuration snapshot, fetching a fresh
+// one from adminserver if the cache is empty or older than CFG_EXPIRATION.
+func GetSystemCfg() (*models.SystemCfg, error) {
+	mu.RLock()
+	if cached != nil && time.Since(cachedAt) < cfgExpiration {
+		defer mu.RUnlock()
+		return cached, nil
+	}
+	mu.RUnlock()
+
+	return Reload()
+}
+
+// Reload unconditionally fetches the configuration from adminserver and
+// replaces the cache, regardless of CFG_EXPIRATION.
+func Reload() (*models.SystemCfg, error) {
+	resp, err := httpClient.Get(adminserverURL() + "/api/configs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach adminserver: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("adminserver returned %d", resp.StatusCode)
+	}
+
+	var cfg models.SystemCfg
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode adminserver response: %v", err)
+	}
+
+	// adminserver only ever stores/serves ciphertext for secret-tagged
+	// fields (email/LDAP passwords, OAuth client secrets); decrypt them
+	// here so the rest of the UI process can keep reading plain config.
+	kr, err := getKeyring()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secret keyring: %v", err)
+	}
+	if err := secret.DecryptStruct(&cfg, kr); err != nil {
+		return nil, fmt.Errorf("failed to decrypt configuration: %v", err)
+	}
+
+	mu.Lock()
+	cached = &cfg
+	cachedAt = time.Now()
+	mu.Unlock()
+
+	return &cfg, nil
+}
+
+// sensitiveKeys is the set of JSON field names (from models.SystemCfg)
+// that must never be written to adminserver in plaintext.
+var sensitiveKeys = secret.SensitiveJSONKeys(reflect.TypeOf(models.SystemCfg{}))
+
+// Update pushes a partial configuration through adminserver (PUT
+// /api/configs) and invalidates the local cache so the next read picks up
+// the change. patch is typically the map[string]interface{} decoded from
+// a POST /api/configurations body; any key that corresponds to a
+// secret-tagged field is encrypted before it leaves this process.
+func Update(patch interface{}) error {
+	if m, ok := patch.(map[string]interface{}); ok {
+		kr, err := getKeyring()
+		if err != nil {
+			return fmt.Errorf("failed to load secret keyring: %v", err)
+		}
+		if err := secret.TransformJSON(m, sensitiveKeys, kr.Encrypt); err != nil {
+			return fmt.Errorf("failed to encrypt configuration update: %v", err)
+		}
+	}
+
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to encode config update: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, adminserverURL()+"/api/configs", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach adminserver: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("adminserver returned %d", resp.StatusCode)
+	}
+
+	_, err = Reload()
+	return err
+}
+
+// AuthMode returns the currently configured AUTH_MODE (e.g. "db_auth",
+// "ldap_auth").
+func AuthMode() (string, error) {
+	cfg, err := GetSystemCfg()
+	if err != nil {
+		return "", err
+	}
+	return cfg.AuthMode, nil
+}
+
+// ExtEndpoint returns the externally-reachable base URL used in outbound
+// links (e.g. the password-reset email).
+func ExtEndpoint() (string, error) {
+	cfg, err := GetSystemCfg()
+	if err != nil {
+		return "", err
+	}
+	return cfg.ExtEndpoint, nil
+}
+
+// Email returns the SMTP settings used to send password-reset mail.
+func Email() (*models.EmailSetting, error) {
+	cfg, err := GetSystemCfg()
+	if err != nil {
+		return nil, err
+	}
+	return &cfg.Email, nil
+}
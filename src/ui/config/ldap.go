@@ -0,0 +1,27 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "github.com/vmware/harbor/src/common/models"
+
+// LDAPConf returns the LDAP connection/search settings the UI container
+// should use for AUTH_MODE=ldap_auth.
+func LDAPConf() (*models.LdapConf, error) {
+	cfg, err := GetSystemCfg()
+	if err != nil {
+		return nil, err
+	}
+	return &cfg.LDAP, nil
+}
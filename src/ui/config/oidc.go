@@ -0,0 +1,47 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/vmware/harbor/src/common/models"
+)
+
+// OIDCProviders returns every OIDC/OAuth2 provider currently configured.
+func OIDCProviders() ([]models.OIDCProvider, error) {
+	cfg, err := GetSystemCfg()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.OIDCProviders, nil
+}
+
+// OIDCProviderByName returns the named provider, or an error if it isn't
+// configured. Names are matched case-sensitively against the `:provider`
+// path segment of the /oauth/login/:provider and /oauth/callback/:provider
+// routes.
+func OIDCProviderByName(name string) (*models.OIDCProvider, error) {
+	providers, err := OIDCProviders()
+	if err != nil {
+		return nil, err
+	}
+	for i := range providers {
+		if providers[i].Name == name {
+			return &providers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("oidc provider %q is not configured", name)
+}
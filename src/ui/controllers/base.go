@@ -16,10 +16,6 @@ package controllers
 
 import (
 	"bytes"
-	"context"
-	"crypto/tls"
-	"crypto/x509"
-	"fmt"
 	"html/template"
 	"net"
 	"net/http"
@@ -30,16 +26,25 @@ import (
 
 	"github.com/astaxie/beego"
 	"github.com/beego/i18n"
-	jwtgo "github.com/dgrijalva/jwt-go"
 	"github.com/vmware/harbor/src/common"
 	"github.com/vmware/harbor/src/common/dao"
 	"github.com/vmware/harbor/src/common/models"
 	"github.com/vmware/harbor/src/common/utils"
 	email_util "github.com/vmware/harbor/src/common/utils/email"
 	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/common/utils/ratelimit"
 	"github.com/vmware/harbor/src/ui/auth"
+	_ "github.com/vmware/harbor/src/ui/auth/db"
+	"github.com/vmware/harbor/src/ui/auth/ldap"
 	"github.com/vmware/harbor/src/ui/config"
-	"golang.org/x/oauth2"
+)
+
+// Reset-password throttling: a handful of requests per email is enough
+// for a legitimate user who fat-fingered the first one, while 30/hour/IP
+// bounds how fast a single client can enumerate addresses.
+var (
+	resetEmailLimiter = ratelimit.New(3, time.Hour)
+	resetIPLimiter    = ratelimit.New(30, time.Hour)
 )
 
 // CommonController handles request from UI that doesn't expect a page, such as /SwitchLanguage /logout ...
@@ -69,8 +74,14 @@ func (cc *CommonController) Login() {
 		Password:  password,
 	})
 	if err != nil {
+		if err == ldap.ErrInvalidCredential {
+			cc.CustomAbort(http.StatusUnauthorized, "")
+		}
+		// A misconfigured or unreachable LDAP server should not look like
+		// a bad password: surface it distinctly so the admin can tell
+		// "wrong credentials" apart from "auth backend is broken".
 		log.Errorf("Error occurred in UserLogin: %v", err)
-		cc.CustomAbort(http.StatusUnauthorized, "")
+		cc.CustomAbort(http.StatusInternalServerError, "auth_backend_error")
 	}
 
 	if user == nil {
@@ -124,6 +135,14 @@ func (cc *CommonController) SendResetEmail() {
 		cc.CustomAbort(http.StatusBadRequest, "invalid email")
 	}
 
+	clientIP := cc.Ctx.Input.IP()
+	if allowed, retryAfter := resetIPLimiter.Allow(clientIP); !allowed {
+		cc.abortTooManyRequests(retryAfter)
+	}
+	if allowed, retryAfter := resetEmailLimiter.Allow(email); !allowed {
+		cc.abortTooManyRequests(retryAfter)
+	}
+
 	queryUser := models.User{Email: email}
 	u, err := dao.GetUser(queryUser)
 	if err != nil {
@@ -131,13 +150,22 @@ func (cc *CommonController) SendResetEmail() {
 		cc.CustomAbort(http.StatusInternalServerError, "Internal error.")
 	}
 	if u == nil {
+		// Respond exactly like the success path below: a 404 here would
+		// let an attacker enumerate which addresses have accounts.
 		log.Debugf("email %s not found", email)
-		cc.CustomAbort(http.StatusNotFound, "email_does_not_exist")
+		cc.Ctx.Output.SetStatus(http.StatusAccepted)
+		return
 	}
 
 	if !isUserResetable(u) {
-		log.Errorf("Resetting password for user with ID: %d is not allowed", u.UserID)
-		cc.CustomAbort(http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		// Same response as the "no such address" branch above: in
+		// ldap_auth/uaa_auth, only user_id==1 is resetable, so a 403 here
+		// would tell an attacker the address belongs to an existing,
+		// non-resetable account -- the same enumeration oracle the 404->202
+		// change above was meant to close.
+		log.Debugf("resetting password for user with ID %d is not allowed", u.UserID)
+		cc.Ctx.Output.SetStatus(http.StatusAccepted)
+		return
 	}
 
 	uuid := utils.GenerateRandomString()
@@ -193,6 +221,19 @@ func (cc *CommonController) SendResetEmail() {
 		log.Errorf("Send email failed: %v", err)
 		cc.CustomAbort(http.StatusInternalServerError, "send_email_failed")
 	}
+
+	cc.Ctx.Output.SetStatus(http.StatusAccepted)
+}
+
+// abortTooManyRequests aborts the request with 429 and a Retry-After
+// header expressing retryAfter in whole seconds (rounded up).
+func (cc *CommonController) abortTooManyRequests(retryAfter time.Duration) {
+	seconds := int(retryAfter.Seconds())
+	if retryAfter%time.Second != 0 {
+		seconds++
+	}
+	cc.Ctx.Output.Header("Retry-After", strconv.Itoa(seconds))
+	cc.CustomAbort(http.StatusTooManyRequests, "too_many_requests")
 }
 
 // ResetPassword handles request from the reset page and reset password
@@ -220,12 +261,18 @@ func (cc *CommonController) ResetPassword() {
 		cc.CustomAbort(http.StatusForbidden, http.StatusText(http.StatusForbidden))
 	}
 
+	if user.ResetExpiresAt.Before(time.Now()) {
+		cc.CustomAbort(http.StatusBadRequest, "reset_uuid_expired")
+	}
+
 	password := cc.GetString("password")
 
 	if password != "" {
 		user.Password = password
 		err = dao.ResetUserPassword(*user)
-		if err != nil {
+		if err == dao.ErrResetTokenExpired {
+			cc.CustomAbort(http.StatusBadRequest, "reset_uuid_expired")
+		} else if err != nil {
 			log.Errorf("Error occurred in ResetUserPassword: %v", err)
 			cc.CustomAbort(http.StatusInternalServerError, "Internal error.")
 		}
@@ -234,86 +281,6 @@ func (cc *CommonController) ResetPassword() {
 	}
 }
 
-// Oauth exchanges OAuth authorization codes for an access token and
-// authenticates (and possibly creates) the user described in the token.
-func (cc *CommonController) Oauth() {
-	// {"name":"Harbor Dev","description":"for Harbor OAuth development","id":"6d3cca7a-5f59-4664-a513-6cb7783d50b0","secret":"7e67a166a29087c8079916e7a4df1c87aa8ea187d547f8e266b7ac98b058ad0c","callback_url":"http://harbor.appfound.co/oauth","signing":{"algorithm":"HS256","key":"a92d1e15853ff92ad0dd772ee3f2a98564526f7d4e3e2892764dbc066b0e61cd"}}
-	customTLSContext := context.TODO()
-	pool, err := x509.SystemCertPool()
-	if err != nil {
-		log.Errorf("error retrieving system cert pool: %v", err)
-		cc.CustomAbort(http.StatusInternalServerError, "SSL error")
-		return
-	}
-
-	client := &http.Client{
-		Timeout: time.Minute,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs:            pool,
-				InsecureSkipVerify: true,
-			},
-		},
-	}
-
-	customTLSContext = context.WithValue(customTLSContext, oauth2.HTTPClient, client)
-
-	cfg, err := config.OAuthConf()
-	if err != nil {
-		log.Errorf("Error loading config: %v", err)
-		cc.CustomAbort(http.StatusInternalServerError, "error loading config")
-		return
-	}
-
-	oc := oauth2.Config{
-		ClientID:     cfg.ClientID,
-		ClientSecret: cfg.ClientSecret,
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  cfg.AuthURL,
-			TokenURL: cfg.TokenURL,
-		},
-	}
-
-	token, err := oc.Exchange(customTLSContext, cc.Input().Get("code"))
-	if err != nil {
-		log.Errorf("Error calling oauth Exchange: %v", err)
-		cc.CustomAbort(http.StatusInternalServerError, "error retrieving oauth token")
-		return
-	}
-
-	data, err := jwtgo.ParseWithClaims(token.AccessToken, &jwtgo.StandardClaims{}, func(t *jwtgo.Token) (interface{}, error) {
-		if t.Method != jwtgo.SigningMethodHS256 {
-			return nil, fmt.Errorf("only HS256 signing is supported")
-		}
-
-		return []byte("a92d1e15853ff92ad0dd772ee3f2a98564526f7d4e3e2892764dbc066b0e61cd"), nil
-	})
-
-	if err != nil {
-		log.Errorf("error parsing JWT: %v", err)
-		cc.CustomAbort(http.StatusInternalServerError, "error parsing oauth response")
-		return
-	}
-
-	log.Debugf("claim data: %+v", data.Claims.(*jwtgo.StandardClaims))
-	user, err := createUser(&models.User{
-		Username: data.Claims.(*jwtgo.StandardClaims).Subject,
-		Email:    fmt.Sprintf("%s@%s", data.Claims.(*jwtgo.StandardClaims).Subject, data.Claims.(*jwtgo.StandardClaims).Issuer),
-	})
-	if err != nil {
-		log.Errorf("error creating user: %v", err)
-		cc.Abort("500")
-		return
-	}
-
-	cc.SetSession("userId", user.UserID)
-	cc.SetSession("username", user.Username)
-	cc.SetSession("isSysAdmin", user.HasAdminRole == 1)
-
-	cc.Redirect("/harbor", http.StatusFound)
-	return
-}
-
 func isUserResetable(u *models.User) bool {
 	if u == nil {
 		return false
@@ -329,15 +296,6 @@ func isUserResetable(u *models.User) bool {
 	return u.UserID == 1
 }
 
-func createUser(u *models.User) (*models.User, error) {
-	err := dao.OnBoardUser(u)
-	if err != nil {
-		return nil, err
-	}
-
-	return u, nil
-}
-
 func init() {
 	//conf/app.conf -> os.Getenv("config_path")
 	configPath := os.Getenv("CONFIG_PATH")
@@ -0,0 +1,56 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/astaxie/beego"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/config"
+)
+
+func init() {
+	beego.Router("/api/configurations", &ConfigAPI{}, "post:Update")
+}
+
+// ConfigAPI lets a sysadmin push a partial configuration update through
+// adminserver, hot-reloading it into every component that reads from
+// src/ui/config without restarting the UI container.
+type ConfigAPI struct {
+	beego.Controller
+}
+
+// Update merges the posted JSON document onto the canonical config held
+// by adminserver, then invalidates this process's config cache.
+func (ca *ConfigAPI) Update() {
+	if isSysAdmin, _ := ca.GetSession("isSysAdmin").(bool); !isSysAdmin {
+		ca.CustomAbort(http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	var patch map[string]interface{}
+	if err := json.Unmarshal(ca.Ctx.Input.RequestBody, &patch); err != nil {
+		ca.CustomAbort(http.StatusBadRequest, "invalid configuration payload")
+		return
+	}
+
+	if err := config.Update(patch); err != nil {
+		log.Errorf("failed to update configuration: %v", err)
+		ca.CustomAbort(http.StatusInternalServerError, "failed to update configuration")
+		return
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/astaxie/beego"
+	"github.com/vmware/harbor/src/common/models"
+	ldaputil "github.com/vmware/harbor/src/common/utils/ldap"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+func init() {
+	beego.Router("/api/ldap/ping", &LDAPAPI{}, "post:Ping")
+	beego.Router("/api/ldap/users/search", &LDAPAPI{}, "post:SearchUsers")
+}
+
+// LDAPAPI lets an admin validate an ad-hoc LdapConf (one that may not yet
+// be saved) before switching AUTH_MODE to ldap_auth.
+type LDAPAPI struct {
+	beego.Controller
+}
+
+type ldapPingResult struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message,omitempty"`
+	ResultCode int    `json:"ldap_result_code,omitempty"`
+}
+
+// Ping attempts a search-account bind and a single search against the
+// posted LdapConf, returning structured success/failure instead of just
+// an HTTP status so the UI can explain *why* it failed.
+func (la *LDAPAPI) Ping() {
+	if isSysAdmin, _ := la.GetSession("isSysAdmin").(bool); !isSysAdmin {
+		la.CustomAbort(http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	conf := la.parseConf()
+
+	session, err := ldaputil.NewSession(conf)
+	if err != nil {
+		la.Data["json"] = ldapPingResult{Success: false, Message: err.Error(), ResultCode: ldaputil.ResultCode(err)}
+		la.ServeJSON()
+		return
+	}
+	defer session.Close()
+
+	if err := session.Bind(); err != nil {
+		la.Data["json"] = ldapPingResult{Success: false, Message: err.Error(), ResultCode: ldaputil.ResultCode(err)}
+		la.ServeJSON()
+		return
+	}
+
+	if _, err := session.SearchUsers(""); err != nil {
+		la.Data["json"] = ldapPingResult{Success: false, Message: err.Error(), ResultCode: ldaputil.ResultCode(err)}
+		la.ServeJSON()
+		return
+	}
+
+	la.Data["json"] = ldapPingResult{Success: true}
+	la.ServeJSON()
+}
+
+// SearchUsers previews which users the posted LdapConf's filter/base DN
+// would match, so an admin can validate it before saving.
+func (la *LDAPAPI) SearchUsers() {
+	if isSysAdmin, _ := la.GetSession("isSysAdmin").(bool); !isSysAdmin {
+		la.CustomAbort(http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	conf := la.parseConf()
+
+	session, err := ldaputil.NewSession(conf)
+	if err != nil {
+		log.Errorf("ldap connection failed: %v", err)
+		la.CustomAbort(http.StatusBadRequest, err.Error())
+		return
+	}
+	defer session.Close()
+
+	if err := session.Bind(); err != nil {
+		log.Errorf("ldap search bind failed: %v", err)
+		la.CustomAbort(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	users, err := session.SearchUsers("")
+	if err != nil {
+		log.Errorf("ldap search failed: %v", err)
+		la.CustomAbort(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	la.Data["json"] = users
+	la.ServeJSON()
+}
+
+func (la *LDAPAPI) parseConf() models.LdapConf {
+	var conf models.LdapConf
+	if err := json.Unmarshal(la.Ctx.Input.RequestBody, &conf); err != nil {
+		log.Errorf("failed to parse ldap conf: %v", err)
+	}
+	return conf
+}
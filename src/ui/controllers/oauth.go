@@ -0,0 +1,146 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/astaxie/beego"
+	"github.com/vmware/harbor/src/common/utils/log"
+	"github.com/vmware/harbor/src/ui/auth"
+	"github.com/vmware/harbor/src/ui/auth/oidc"
+	"github.com/vmware/harbor/src/ui/config"
+)
+
+func init() {
+	beego.Router("/oauth/login/:provider", &CommonController{}, "get:OauthLogin")
+	beego.Router("/oauth/callback/:provider", &CommonController{}, "get:OauthCallback")
+}
+
+// OauthLogin redirects the browser to the named provider's authorization
+// endpoint, stashing state/nonce/PKCE verifier in the session so
+// OauthCallback can validate them when the browser comes back.
+func (cc *CommonController) OauthLogin() {
+	name := cc.Ctx.Input.Param(":provider")
+
+	providerCfg, err := config.OIDCProviderByName(name)
+	if err != nil {
+		log.Errorf("unknown oauth provider %q: %v", name, err)
+		cc.CustomAbort(http.StatusNotFound, "unknown oauth provider")
+		return
+	}
+
+	client, err := oidc.ForProvider(*providerCfg)
+	if err != nil {
+		log.Errorf("failed to initialize oidc client for provider %q: %v", name, err)
+		cc.CustomAbort(http.StatusInternalServerError, "error loading oidc provider")
+		return
+	}
+
+	state, err := oidc.NewState()
+	if err != nil {
+		log.Errorf("failed to generate oauth state: %v", err)
+		cc.CustomAbort(http.StatusInternalServerError, "internal error")
+		return
+	}
+	nonce, err := oidc.NewState()
+	if err != nil {
+		log.Errorf("failed to generate oauth nonce: %v", err)
+		cc.CustomAbort(http.StatusInternalServerError, "internal error")
+		return
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		log.Errorf("failed to generate PKCE code_verifier: %v", err)
+		cc.CustomAbort(http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	cc.SetSession("oauthProvider", name)
+	cc.SetSession("oauthState", state)
+	cc.SetSession("oauthNonce", nonce)
+	cc.SetSession("oauthVerifier", verifier)
+
+	cc.Redirect(client.AuthCodeURL(state, nonce, oidc.CodeChallengeS256(verifier)), http.StatusFound)
+}
+
+// OauthCallback validates the authorization response, exchanges the code
+// for an id_token, verifies it and logs the mapped user in, onboarding
+// them on first login.
+func (cc *CommonController) OauthCallback() {
+	name := cc.Ctx.Input.Param(":provider")
+
+	if cc.GetSession("oauthProvider") != name {
+		cc.CustomAbort(http.StatusBadRequest, "oauth provider mismatch")
+		return
+	}
+	state, _ := cc.GetSession("oauthState").(string)
+	nonce, _ := cc.GetSession("oauthNonce").(string)
+	verifier, _ := cc.GetSession("oauthVerifier").(string)
+	cc.DelSession("oauthProvider")
+	cc.DelSession("oauthState")
+	cc.DelSession("oauthNonce")
+	cc.DelSession("oauthVerifier")
+
+	if state == "" || cc.GetString("state") != state {
+		cc.CustomAbort(http.StatusBadRequest, "invalid oauth state")
+		return
+	}
+
+	providerCfg, err := config.OIDCProviderByName(name)
+	if err != nil {
+		log.Errorf("unknown oauth provider %q: %v", name, err)
+		cc.CustomAbort(http.StatusNotFound, "unknown oauth provider")
+		return
+	}
+	client, err := oidc.ForProvider(*providerCfg)
+	if err != nil {
+		log.Errorf("failed to initialize oidc client for provider %q: %v", name, err)
+		cc.CustomAbort(http.StatusInternalServerError, "error loading oidc provider")
+		return
+	}
+
+	claims, err := client.Exchange(cc.Ctx.Request.Context(), cc.GetString("code"), verifier, nonce)
+	if err != nil {
+		log.Errorf("oauth callback failed for provider %q: %v", name, err)
+		cc.CustomAbort(http.StatusUnauthorized, "oauth authentication failed")
+		return
+	}
+
+	user, err := client.ToUser(claims)
+	if err != nil {
+		log.Errorf("failed to map oauth claims to a user: %v", err)
+		cc.CustomAbort(http.StatusUnauthorized, "oauth authentication failed")
+		return
+	}
+
+	// client.MapRole returns a project role (see models.OIDCGroupRoleMapping),
+	// not a system role -- it has no project-membership path to land in
+	// yet, so it is intentionally not applied to user.HasAdminRole (the
+	// global sysadmin flag). Do not wire it in here; that would grant
+	// every project-admin group global sysadmin.
+
+	if err := auth.OnBoardUser(user); err != nil {
+		log.Errorf("failed to onboard oauth user %q: %v", user.Username, err)
+		cc.CustomAbort(http.StatusInternalServerError, "error creating user")
+		return
+	}
+
+	cc.SetSession("userId", user.UserID)
+	cc.SetSession("username", user.Username)
+	cc.SetSession("isSysAdmin", user.HasAdminRole == 1)
+
+	cc.Redirect("/harbor", http.StatusFound)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/astaxie/beego"
+	"github.com/vmware/harbor/src/common/dao"
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/utils/log"
+)
+
+const defaultUserPageSize = 25
+
+func init() {
+	beego.Router("/api/users", &UserAPI{}, "get:List")
+}
+
+// UserAPI exposes sysadmin-only user lookups, replacing ad-hoc
+// boolean checks (see CommonController.UserExists) with a real,
+// paginated search.
+type UserAPI struct {
+	beego.Controller
+}
+
+// List returns a page of users matching the `username`/`email` substring
+// filters, reporting the total match count via X-Total-Count and prev/next
+// links via an RFC-5988 Link header.
+func (ua *UserAPI) List() {
+	if isSysAdmin, _ := ua.GetSession("isSysAdmin").(bool); !isSysAdmin {
+		ua.CustomAbort(http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	page, err := ua.GetInt64("page", 1)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := ua.GetInt64("page_size", defaultUserPageSize)
+	if err != nil || pageSize < 1 {
+		pageSize = defaultUserPageSize
+	}
+
+	query := models.UserQuery{
+		Username: ua.GetString("username"),
+		Email:    ua.GetString("email"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	users, total, err := dao.ListUsers(query)
+	if err != nil {
+		log.Errorf("failed to list users: %v", err)
+		ua.CustomAbort(http.StatusInternalServerError, "Internal error.")
+		return
+	}
+
+	ua.Ctx.Output.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	if link := ua.linkHeader(query, total); link != "" {
+		ua.Ctx.Output.Header("Link", link)
+	}
+
+	ua.Data["json"] = users
+	ua.ServeJSON()
+}
+
+// linkHeader builds the RFC-5988 Link header advertising prev/next pages,
+// preserving the caller's username/email filters on each link.
+func (ua *UserAPI) linkHeader(query models.UserQuery, total int64) string {
+	base := ua.Ctx.Request.URL.Path
+	qs := func(page int64) string {
+		v := make([]string, 0, 3)
+		if query.Username != "" {
+			v = append(v, "username="+query.Username)
+		}
+		if query.Email != "" {
+			v = append(v, "email="+query.Email)
+		}
+		v = append(v, fmt.Sprintf("page=%d", page), fmt.Sprintf("page_size=%d", query.PageSize))
+		return base + "?" + strings.Join(v, "&")
+	}
+
+	links := make([]string, 0, 2)
+	if query.Page > 1 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, qs(query.Page-1)))
+	}
+	if query.Page*query.PageSize < total {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, qs(query.Page+1)))
+	}
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command secretrotate re-encrypts every secret-tagged field in
+// adminserver's configuration under a new SECRET_KEY, for use after
+// rotating the key file at KEY_PATH. It must be run against the *old* key
+// before the new key is deployed everywhere else, since it needs both to
+// decrypt the existing values and re-encrypt them.
+//
+// Usage:
+//
+//	secretrotate -old-key /path/to/old/key -new-key /path/to/new/key \
+//		-adminserver-url http://adminserver
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/vmware/harbor/src/common/models"
+	"github.com/vmware/harbor/src/common/secret"
+)
+
+func main() {
+	oldKeyPath := flag.String("old-key", "", "path to the SECRET_KEY file currently in use")
+	newKeyPath := flag.String("new-key", "", "path to the new SECRET_KEY file")
+	adminserverURL := flag.String("adminserver-url", "http://adminserver", "adminserver base URL")
+	flag.Parse()
+
+	if *oldKeyPath == "" || *newKeyPath == "" {
+		log.Fatal("both -old-key and -new-key are required")
+	}
+
+	oldKeyring, err := secret.NewKeyringFromFile(*oldKeyPath)
+	if err != nil {
+		log.Fatalf("failed to load old key: %v", err)
+	}
+	newKeyring, err := secret.NewKeyringFromFile(*newKeyPath)
+	if err != nil {
+		log.Fatalf("failed to load new key: %v", err)
+	}
+
+	cfg, err := fetchConfig(*adminserverURL)
+	if err != nil {
+		log.Fatalf("failed to fetch configuration: %v", err)
+	}
+
+	if err := secret.DecryptStruct(cfg, oldKeyring); err != nil {
+		log.Fatalf("failed to decrypt configuration with old key: %v", err)
+	}
+	if err := secret.EncryptStruct(cfg, newKeyring); err != nil {
+		log.Fatalf("failed to encrypt configuration with new key: %v", err)
+	}
+
+	if err := pushConfig(*adminserverURL, cfg); err != nil {
+		log.Fatalf("failed to write rotated configuration: %v", err)
+	}
+
+	fmt.Println("secret rotation complete")
+}
+
+func fetchConfig(adminserverURL string) (*models.SystemCfg, error) {
+	resp, err := http.Get(adminserverURL + "/api/configs")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("adminserver returned %d", resp.StatusCode)
+	}
+
+	var cfg models.SystemCfg
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func pushConfig(adminserverURL string, cfg *models.SystemCfg) error {
+	body, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, adminserverURL+"/api/configs", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("adminserver returned %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}